@@ -0,0 +1,310 @@
+/*
+Package calibration drives an end-to-end Short-Open-Load (SOLT) calibration
+of a pocket.VNA using an rfswitch.Switch to present the three one-port
+standards, then applies the resulting error terms to correct subsequent
+DUT sweeps.
+
+A full 12-term two-port model is not implemented here; only S11 is
+corrected, with the standard 1-port, 3-term model (directivity, source
+match, reflection tracking), which is sufficient for the one-port-at-a-time
+standards an rfswitch.Switch can present. S21, S12 and S22 are passed
+through uncorrected.
+*/
+package calibration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/timdrysdale/go-pocketvna/pkg/pocket"
+	"github.com/timdrysdale/go-pocketvna/pkg/rfswitch"
+)
+
+// Calibrator wraps a pocket.VNA with SOLT calibration. It embeds *pocket.VNA
+// so it can stand in wherever a VNA is expected, extending HandleCommand
+// with the calibration commands in types.go and falling through to the
+// embedded VNA for everything else.
+type Calibrator struct {
+	*pocket.VNA
+	Switch *rfswitch.Switch
+	Ctx    context.Context
+
+	cal *CalSet
+}
+
+// New returns a Calibrator driving vna through sw. It starts Uncalibrated.
+func New(vna *pocket.VNA, sw *rfswitch.Switch) *Calibrator {
+	return &Calibrator{VNA: vna, Switch: sw}
+}
+
+// Uncalibrated is a passthrough correction: it returns raw unchanged, for
+// use before a CalSet has been captured or loaded.
+func Uncalibrated(raw []pocket.SParam) []pocket.SParam {
+	return raw
+}
+
+// Run mirrors pocket.VNA.Run, but dispatches through the Calibrator's own
+// HandleCommand so calibration can be requested over the same
+// request/response channels as any other VNA command. Commands wrapped in
+// a pocket.Envelope are unwrapped and the result rewrapped with the same
+// ID, matching pocket.VNA.Run's correlation-ID handling - Run cannot rely
+// on embedding to pick that up, since Go has no virtual dispatch and
+// pocket.VNA.Run would call pocket.VNA.HandleCommand, not Calibrator's.
+func (c *Calibrator) Run(command <-chan interface{}, result chan<- interface{}, ctx context.Context) {
+
+	c.Ctx = ctx
+
+	err := c.Connect()
+
+	if err != nil {
+		result <- pocket.CustomResult{Message: err.Error()}
+		return
+	}
+
+	for {
+		select {
+
+		case cmd := <-command:
+
+			if e, ok := cmd.(pocket.Envelope); ok {
+				result <- pocket.Envelope{ID: e.ID, Command: c.HandleCommand(e.Command)}
+				continue
+			}
+
+			result <- c.HandleCommand(cmd)
+
+		case <-ctx.Done():
+			err := c.Disconnect()
+			if err != nil {
+				result <- pocket.CustomResult{Message: err.Error()}
+			}
+			return
+		}
+	}
+}
+
+// HandleCommand extends pocket.VNA.HandleCommand with the calibration
+// commands, falling through to the embedded VNA for anything it does not
+// recognise.
+func (c *Calibrator) HandleCommand(command interface{}) interface{} {
+
+	switch cmd := command.(type) {
+
+	case CalibrateRequest:
+
+		cs, err := c.Calibrate(c.Ctx, cmd.Freq)
+
+		if err != nil {
+			return pocket.CustomResult{Message: err.Error()}
+		}
+
+		cmd.Result = cs
+
+		return cmd
+
+	case ApplyCalibration:
+
+		cmd.Result = Apply(c.cal, cmd.Raw)
+
+		return cmd
+
+	case SaveCalSet:
+
+		if err := c.Save(cmd.Path); err != nil {
+			return pocket.CustomResult{Message: err.Error()}
+		}
+
+		return cmd
+
+	case LoadCalSet:
+
+		if err := c.Load(cmd.Path); err != nil {
+			return pocket.CustomResult{Message: err.Error()}
+		}
+
+		return cmd
+
+	default:
+		return c.VNA.HandleCommand(command)
+	}
+}
+
+// Calibrate sequences the switch through Short, Open and Load, measuring
+// S11 at each frequency in freq, and solves the 3-term error model at each
+// point. The resulting CalSet is stored on the Calibrator (for later
+// Apply/Save calls) and returned.
+func (c *Calibrator) Calibrate(ctx context.Context, freq []uint64) (*CalSet, error) {
+
+	short, err := c.measure(ctx, c.Switch.SetShort, freq)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := c.measure(ctx, c.Switch.SetOpen, freq)
+	if err != nil {
+		return nil, err
+	}
+
+	load, err := c.measure(ctx, c.Switch.SetLoad, freq)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]ErrorTerms, len(freq))
+
+	for i := range freq {
+		terms[i] = solve3Term(short[i], open[i], load[i])
+	}
+
+	cs := &CalSet{Freq: freq, Terms: terms}
+
+	c.cal = cs
+
+	return cs, nil
+}
+
+// Apply corrects raw using cs, matching points by frequency. Points in raw
+// whose frequency is not present in cs are returned unchanged. If cs is
+// nil, Apply behaves like Uncalibrated.
+func Apply(cs *CalSet, raw []pocket.SParam) []pocket.SParam {
+
+	if cs == nil {
+		return Uncalibrated(raw)
+	}
+
+	terms := make(map[uint64]ErrorTerms, len(cs.Freq))
+
+	for i, f := range cs.Freq {
+		terms[f] = cs.Terms[i]
+	}
+
+	out := make([]pocket.SParam, len(raw))
+
+	for i, s := range raw {
+
+		out[i] = s
+
+		t, ok := terms[s.Freq]
+		if !ok {
+			continue
+		}
+
+		out[i].S11 = correct(toComplex(s.S11), t)
+	}
+
+	return out
+}
+
+// Save persists the Calibrator's current CalSet to path as JSON, so a
+// session can reload it later with Load.
+func (c *Calibrator) Save(path string) error {
+
+	if c.cal == nil {
+		return errors.New("no calibration to save")
+	}
+
+	data, err := json.MarshalIndent(c.cal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Load reads a CalSet previously written by Save and uses it for
+// subsequent Apply/ApplyCalibration calls.
+func (c *Calibrator) Load(path string) error {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cs := new(CalSet)
+
+	if err := json.Unmarshal(data, cs); err != nil {
+		return err
+	}
+
+	c.cal = cs
+
+	return nil
+}
+
+func (c *Calibrator) measure(ctx context.Context, setPort func() error, freq []uint64) ([]pocket.Complex, error) {
+
+	if err := setPort(); err != nil {
+		return nil, err
+	}
+
+	s11 := make([]pocket.Complex, len(freq))
+
+	for i, f := range freq {
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		result, err := c.SingleQuery(pocket.SingleQuery{
+			Freq:   f,
+			Avg:    1,
+			Select: pocket.SParamSelect{S11: true},
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		s11[i] = result.Result.S11
+	}
+
+	return s11, nil
+}
+
+// solve3Term solves the 1-port, 3-term error model from the raw reflection
+// measurements of the Short (-1), Open (+1) and Load (0) standards, which
+// are assumed ideal.
+func solve3Term(short, open, load pocket.Complex) ErrorTerms {
+
+	mShort := toComplex(short)
+	mOpen := toComplex(open)
+	mLoad := toComplex(load)
+
+	edf := mLoad
+
+	a := mShort - edf
+	b := mOpen - edf
+
+	esf := (a + b) / (b - a)
+	erf := -a * (1 + esf)
+
+	return ErrorTerms{
+		Directivity:        fromComplex(edf),
+		SourceMatch:        fromComplex(esf),
+		ReflectionTracking: fromComplex(erf),
+	}
+}
+
+// correct applies the 3-term error model to a single raw measurement.
+func correct(measured complex128, t ErrorTerms) pocket.Complex {
+
+	edf := toComplex(t.Directivity)
+	esf := toComplex(t.SourceMatch)
+	erf := toComplex(t.ReflectionTracking)
+
+	actual := (measured - edf) / (erf + esf*(measured-edf))
+
+	return fromComplex(actual)
+}
+
+func toComplex(c pocket.Complex) complex128 {
+	return complex(c.Real, c.Imag)
+}
+
+func fromComplex(c complex128) pocket.Complex {
+	return pocket.Complex{Real: real(c), Imag: imag(c)}
+}
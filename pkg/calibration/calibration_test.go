@@ -0,0 +1,84 @@
+package calibration
+
+import (
+	"math"
+	"testing"
+
+	"github.com/timdrysdale/go-pocketvna/pkg/pocket"
+)
+
+func closeEnough(a, b pocket.Complex) bool {
+	const tol = 1e-9
+	return math.Abs(a.Real-b.Real) < tol && math.Abs(a.Imag-b.Imag) < tol
+}
+
+// forwardModel is the inverse of correct: it predicts what a raw
+// measurement would be for a given true reflection coefficient actual
+// under the 1-port, 3-term error model, given ideal Short (-1), Open (+1)
+// and Load (0) standards. Used to build synthetic measurements for
+// solve3Term/correct without needing an rfswitch or pocket.VNA.
+func forwardModel(actual complex128, t ErrorTerms) complex128 {
+
+	edf := toComplex(t.Directivity)
+	esf := toComplex(t.SourceMatch)
+	erf := toComplex(t.ReflectionTracking)
+
+	return edf + actual*erf/(1-actual*esf)
+}
+
+// TestSolve3TermRecoversKnownErrorTerms checks that solve3Term, given
+// measurements of ideal Short/Open/Load standards synthesised from a known
+// error model, recovers that same error model.
+func TestSolve3TermRecoversKnownErrorTerms(t *testing.T) {
+
+	want := ErrorTerms{
+		Directivity:        pocket.Complex{Real: 0.05, Imag: 0.02},
+		SourceMatch:        pocket.Complex{Real: 0.1, Imag: -0.05},
+		ReflectionTracking: pocket.Complex{Real: 0.9, Imag: 0.1},
+	}
+
+	short := fromComplex(forwardModel(-1, want))
+	open := fromComplex(forwardModel(1, want))
+	load := fromComplex(forwardModel(0, want))
+
+	got := solve3Term(short, open, load)
+
+	if !closeEnough(got.Directivity, want.Directivity) {
+		t.Errorf("Directivity = %+v, want %+v", got.Directivity, want.Directivity)
+	}
+	if !closeEnough(got.SourceMatch, want.SourceMatch) {
+		t.Errorf("SourceMatch = %+v, want %+v", got.SourceMatch, want.SourceMatch)
+	}
+	if !closeEnough(got.ReflectionTracking, want.ReflectionTracking) {
+		t.Errorf("ReflectionTracking = %+v, want %+v", got.ReflectionTracking, want.ReflectionTracking)
+	}
+}
+
+// TestCorrectRecoversDUTReflection checks that applying the error terms
+// solve3Term derived from Short/Open/Load standards to a simulated DUT
+// measurement recovers the DUT's true reflection coefficient.
+func TestCorrectRecoversDUTReflection(t *testing.T) {
+
+	terms := ErrorTerms{
+		Directivity:        pocket.Complex{Real: 0.05, Imag: 0.02},
+		SourceMatch:        pocket.Complex{Real: 0.1, Imag: -0.05},
+		ReflectionTracking: pocket.Complex{Real: 0.9, Imag: 0.1},
+	}
+
+	short := fromComplex(forwardModel(-1, terms))
+	open := fromComplex(forwardModel(1, terms))
+	load := fromComplex(forwardModel(0, terms))
+
+	derived := solve3Term(short, open, load)
+
+	wantActual := complex(0.3, -0.1)
+
+	measured := fromComplex(forwardModel(wantActual, terms))
+
+	got := correct(toComplex(measured), derived)
+	gotActual := toComplex(got)
+
+	if math.Abs(real(gotActual)-real(wantActual)) > 1e-6 || math.Abs(imag(gotActual)-imag(wantActual)) > 1e-6 {
+		t.Fatalf("correct() = %v, want %v", gotActual, wantActual)
+	}
+}
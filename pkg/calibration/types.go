@@ -0,0 +1,49 @@
+package calibration
+
+import "github.com/timdrysdale/go-pocketvna/pkg/pocket"
+
+// CalSet holds the per-frequency error terms produced by a SOLT calibration
+// run, along with the frequency list they apply to. Freq and Terms are
+// kept in lock-step so a CalSet can be round-tripped through JSON and
+// reloaded in a later session.
+type CalSet struct {
+	Freq  []uint64     `json:"freq"`
+	Terms []ErrorTerms `json:"terms"`
+}
+
+// ErrorTerms is the simplified 1-port, 3-term error model (directivity,
+// source match, reflection tracking) solved from the Short-Open-Load
+// standards at a single frequency point.
+type ErrorTerms struct {
+	Directivity        pocket.Complex `json:"directivity"`
+	SourceMatch        pocket.Complex `json:"sourceMatch"`
+	ReflectionTracking pocket.Complex `json:"reflectionTracking"`
+}
+
+// CalibrateRequest asks a Calibrator to run a fresh SOLT calibration over
+// Freq and store the resulting CalSet, returning it in Result.
+type CalibrateRequest struct {
+	Freq   []uint64
+	Result *CalSet
+}
+
+// ApplyCalibration asks a Calibrator to correct a previously captured set
+// of raw S-parameters using whichever CalSet it currently holds, returning
+// the corrected values in Result. If no CalSet is loaded, Result is Raw
+// unchanged (see Uncalibrated).
+type ApplyCalibration struct {
+	Raw    []pocket.SParam
+	Result []pocket.SParam
+}
+
+// SaveCalSet asks a Calibrator to persist its current CalSet to Path as
+// JSON, so it can be reloaded in a later session with LoadCalSet.
+type SaveCalSet struct {
+	Path string
+}
+
+// LoadCalSet asks a Calibrator to load a CalSet from Path and use it for
+// subsequent ApplyCalibration commands.
+type LoadCalSet struct {
+	Path string
+}
@@ -0,0 +1,184 @@
+package pocket
+
+/*
+#cgo CFLAGS: -g -Wall
+#cgo LDFLAGS: -L. -lPocketVnaApi_x64
+#include "pocketvna.h"
+*/
+import "C"
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/* @brief Enumerate every pocketVNA currently attached, opening a handle to
+   each. Callers are responsible for releasing any handle they do not keep.
+
+       @ingroup API
+       @param handles  Array to receive one DeviceHandler per device found
+       @param maxCount Capacity of @p handles
+       @param count    Number of devices actually found (<= maxCount)
+
+       @returns
+           This function returns Result: 'Ok' on success, or any other 'Result'
+
+   PVNA_EXPORTED PVNA_Res   pocketvna_list_devices(PVNA_DeviceHandler * handles, size_t maxCount, size_t * count);
+*/
+
+/* @brief Read the serial number and a human-readable descriptor for an
+   already-open device handle.
+
+       @ingroup API
+       @param handle        A pointer to Device.
+       @param serial        Buffer to receive a NUL-terminated serial number
+       @param serialLen     Capacity of @p serial
+       @param descriptor    Buffer to receive a NUL-terminated descriptor
+       @param descriptorLen Capacity of @p descriptor
+
+       @returns
+           This function returns Result: 'Ok' on success, 'PVNA_Res_InvalidHandle' if handle is invalid
+
+   PVNA_EXPORTED PVNA_Res   pocketvna_get_device_info(const PVNA_DeviceHandler handle, char * serial, size_t serialLen, char * descriptor, size_t descriptorLen);
+*/
+
+// maxEnumeratedDevices bounds how many attached pocketVNAs ListDevices and
+// selectDeviceHandle will enumerate in one call.
+const maxEnumeratedDevices = 16
+
+// DeviceInfo describes one pocketVNA found by ListDevices.
+type DeviceInfo struct {
+	Serial     string
+	Descriptor string
+}
+
+// DeviceSelector picks which pocketVNA a VNA should attach to: by serial
+// number, by the order devices were enumerated in, or by a descriptor
+// substring match. The zero value selects the first device found, matching
+// the previous GetFirstDeviceHandle-only behaviour.
+type DeviceSelector struct {
+	Serial     string
+	Descriptor string
+	Index      int
+}
+
+// SelectDevice asks a VNA to disconnect from its current device (if any)
+// and reconnect to the one matching Selector.
+type SelectDevice struct {
+	Selector DeviceSelector
+}
+
+// releaseHandles releases every handle in handles except the one at index
+// keep (pass -1 to release all of them). Failures are logged rather than
+// returned, since this is itself cleanup run after an error or on every
+// return path: letting one bad release abort the rest would leave the
+// remaining handles open, which on this hardware API behaves as an
+// exclusive device lock until ForceUnlockDevices is called out-of-band.
+func releaseHandles(handles []C.PVNA_DeviceHandler, keep int) {
+
+	for i, h := range handles {
+
+		if i == keep {
+			continue
+		}
+
+		if err := releaseHandle(h); err != nil {
+			log.WithField("error", err).Warning("pocket: could not release device handle")
+		}
+	}
+}
+
+// ListDevices enumerates every pocketVNA currently attached.
+func ListDevices() ([]DeviceInfo, error) {
+
+	handles := [maxEnumeratedDevices]C.PVNA_DeviceHandler{}
+	count := C.size_t(0)
+
+	result := C.pocketvna_list_devices(&handles[0], C.size_t(maxEnumeratedDevices), &count)
+
+	if err := decode(result); err != nil {
+		return nil, err
+	}
+
+	defer releaseHandles(handles[:count], -1)
+
+	devices := make([]DeviceInfo, 0, int(count))
+
+	for i := 0; i < int(count); i++ {
+
+		info, err := deviceInfo(handles[i])
+		if err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, info)
+	}
+
+	return devices, nil
+}
+
+// selectDeviceHandle enumerates the attached devices, opens the one
+// matching sel, and releases the handles of every other device it opened
+// along the way - including on every early-return path, so a selector
+// that matches nothing (or a deviceInfo read that fails partway through)
+// cannot leak the handles already opened by enumeration.
+func selectDeviceHandle(sel DeviceSelector) (C.PVNA_DeviceHandler, error) {
+
+	handles := [maxEnumeratedDevices]C.PVNA_DeviceHandler{}
+	count := C.size_t(0)
+
+	result := C.pocketvna_list_devices(&handles[0], C.size_t(maxEnumeratedDevices), &count)
+
+	if err := decode(result); err != nil {
+		return nil, err
+	}
+
+	chosen := -1
+
+	defer func() { releaseHandles(handles[:count], chosen) }()
+
+	for i := 0; i < int(count); i++ {
+
+		if sel.Serial != "" || sel.Descriptor != "" {
+
+			info, err := deviceInfo(handles[i])
+			if err != nil {
+				return nil, err
+			}
+
+			if sel.Serial != "" && info.Serial != sel.Serial {
+				continue
+			}
+
+			if sel.Descriptor != "" && !strings.Contains(info.Descriptor, sel.Descriptor) {
+				continue
+			}
+
+		} else if i != sel.Index {
+			continue
+		}
+
+		chosen = i
+		break
+	}
+
+	if chosen == -1 {
+		return nil, fmt.Errorf("pocket: no attached device matched selector %+v", sel)
+	}
+
+	return handles[chosen], nil
+}
+
+func deviceInfo(handle C.PVNA_DeviceHandler) (DeviceInfo, error) {
+
+	serial := make([]C.char, 64)
+	descriptor := make([]C.char, 256)
+
+	result := C.pocketvna_get_device_info(handle, &serial[0], C.size_t(len(serial)), &descriptor[0], C.size_t(len(descriptor)))
+
+	return DeviceInfo{
+		Serial:     C.GoString(&serial[0]),
+		Descriptor: C.GoString(&descriptor[0]),
+	}, decode(result)
+}
@@ -9,6 +9,8 @@ ReleaseHandle
 GetReasonableFrequencyRange
 SingleQuery
 RangeQuery
+SaveTouchstone
+SelectDevice
 
 Function call result codes are decoded as required, into strings as specified in pocket.h
 
@@ -33,23 +35,46 @@ import (
 
 // does not compile if in types.go ("C undefined")
 type VNA struct {
-	handle C.PVNA_DeviceHandler
+	handle   C.PVNA_DeviceHandler
+	selector DeviceSelector
+
+	ctx      context.Context
+	progress chan<- ProgressEvent
+
+	lastRangeQuery []SParam
 }
 
-func New(ctx context.Context) VNAService {
+// New returns a VNAService driving the first device GetFirstDeviceHandle
+// finds. An optional selector picks a specific device instead, e.g. for
+// use with a DevicePool driving several pocketVNAs at once.
+func New(ctx context.Context, selector ...DeviceSelector) VNAService {
 
 	request := make(chan interface{}, 2)
 	response := make(chan interface{}, 2)
+	progress := make(chan ProgressEvent, 16)
+
 	v := NewVNA()
+	v.progress = progress
+
+	if len(selector) > 0 {
+		v.selector = selector[0]
+	}
+
 	go v.Run(request, response, ctx)
 
-	return VNAService{
+	s := VNAService{
 		VNA:      v,
 		Ctx:      ctx,
 		Request:  request,
 		Response: response,
+		Progress: progress,
 		Timeout:  time.Second,
+		reports:  newPendingResults(),
 	}
+
+	go s.demux()
+
+	return s
 }
 
 func NewVNA() *VNA {
@@ -65,6 +90,8 @@ There are two uni-directional channels, one to receive commands, the other to re
 
 func (v *VNA) Run(command <-chan interface{}, result chan<- interface{}, ctx context.Context) {
 
+	v.ctx = ctx
+
 	err := v.Connect()
 
 	if err != nil {
@@ -77,6 +104,11 @@ func (v *VNA) Run(command <-chan interface{}, result chan<- interface{}, ctx con
 
 		case cmd := <-command:
 
+			if e, ok := cmd.(Envelope); ok {
+				result <- Envelope{ID: e.ID, Command: v.HandleCommand(e.Command)}
+				continue
+			}
+
 			result <- v.HandleCommand(cmd)
 
 		case <-ctx.Done():
@@ -89,8 +121,24 @@ func (v *VNA) Run(command <-chan interface{}, result chan<- interface{}, ctx con
 	}
 }
 
+// Connect attaches to the device chosen by v.selector (the zero
+// DeviceSelector selects the first device found, matching the previous
+// GetFirstDeviceHandle-only behaviour).
 func (v *VNA) Connect() error {
-	handle, err := getFirstDeviceHandle()
+
+	if v.selector == (DeviceSelector{}) {
+
+		handle, err := getFirstDeviceHandle()
+		if err != nil {
+			return err
+		}
+
+		v.handle = handle
+
+		return nil
+	}
+
+	handle, err := selectDeviceHandle(v.selector)
 	if err != nil {
 		return err
 	}
@@ -161,6 +209,32 @@ func (v *VNA) HandleCommand(command interface{}) interface{} {
 
 		return result
 
+	case SaveTouchstone:
+
+		err := v.SaveTouchstone(command.(SaveTouchstone))
+
+		if err != nil {
+			return CustomResult{Message: err.Error()}
+		}
+
+		return command
+
+	case SelectDevice:
+
+		cmd := command.(SelectDevice)
+
+		if err := v.Disconnect(); err != nil {
+			return CustomResult{Message: err.Error()}
+		}
+
+		v.selector = cmd.Selector
+
+		if err := v.Connect(); err != nil {
+			return CustomResult{Message: err.Error()}
+		}
+
+		return command
+
 	default:
 		return CustomResult{
 			Message: "Unknown Command",
@@ -178,7 +252,7 @@ func (v *VNA) RangeQuery(r RangeQuery) (RangeQuery, error) {
 		distr = 2
 	}
 
-	sparams, err := rangeQuery(v.handle, r.Range.Start, r.Range.End, r.Size, distr, r.Avg, r.Select)
+	sparams, err := rangeQuery(v.handle, r.Range.Start, r.Range.End, r.Size, distr, r.Avg, r.Select, v.ctx, v.progress)
 
 	if err != nil {
 		return r, err
@@ -186,6 +260,8 @@ func (v *VNA) RangeQuery(r RangeQuery) (RangeQuery, error) {
 
 	r.Result = sparams
 
+	v.lastRangeQuery = sparams
+
 	return r, err
 }
 
@@ -391,14 +467,30 @@ const (
 	Log
 )
 
-// We do not implement the callback for this version ...
-func rangeQuery(handle C.PVNA_DeviceHandler, start, end uint64, size int, distr int, avg uint16, p SParamSelect) ([]SParam, error) {
+// rangeQuery runs a full sweep. If ctx and progress are both non-nil, it
+// wires up a PVNA_ProgressCallBack so the C library reports back after
+// every frequency point: each point is forwarded as a ProgressEvent on
+// progress (best-effort - a slow receiver gets points dropped rather than
+// stalling the device), and the callback consults ctx.Done() so that
+// cancelling ctx aborts the sweep from the C side cleanly, without leaking
+// the device handle.
+func rangeQuery(handle C.PVNA_DeviceHandler, start, end uint64, size int, distr int, avg uint16, p SParamSelect, ctx context.Context, progress chan<- ProgressEvent) ([]SParam, error) {
 
 	S11 := [512]C.PVNA_Sparam{}
 	S12 := [512]C.PVNA_Sparam{}
 	S21 := [512]C.PVNA_Sparam{}
 	S22 := [512]C.PVNA_Sparam{}
 
+	var cb *C.PVNA_ProgressCallBack
+
+	if ctx != nil && progress != nil {
+		id := registerProgress(ctx, progress)
+		defer unregisterProgress(id)
+
+		progressCB := newProgressCallback(id)
+		cb = &progressCB
+	}
+
 	result := C.pocketvna_range_query(handle,
 		C.PVNA_Frequency(start),
 		C.PVNA_Frequency(end),
@@ -411,7 +503,7 @@ func rangeQuery(handle C.PVNA_DeviceHandler, start, end uint64, size int, distr
 		&S12[0],
 		&S22[0],
 
-		nil)
+		cb)
 
 	var ff []uint64
 
@@ -0,0 +1,144 @@
+package pocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DevicePool runs one VNAService per attached pocketVNA, so a rig with
+// several devices (e.g. one per DUT position) can be driven concurrently
+// from a single process. It periodically re-enumerates attached devices
+// and pushes DeviceInfo on Added/Removed as they come and go.
+type DevicePool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	devices map[string]poolEntry
+
+	Added   chan DeviceInfo
+	Removed chan DeviceInfo
+}
+
+type poolEntry struct {
+	Service VNAService
+	cancel  context.CancelFunc
+}
+
+// NewDevicePool starts a DevicePool that re-enumerates attached devices
+// every pollEvery, starting a VNAService (selected by serial number) for
+// each one newly seen.
+func NewDevicePool(ctx context.Context, pollEvery time.Duration) *DevicePool {
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	p := &DevicePool{
+		ctx:     ctx,
+		cancel:  cancel,
+		devices: make(map[string]poolEntry),
+		Added:   make(chan DeviceInfo, 4),
+		Removed: make(chan DeviceInfo, 4),
+	}
+
+	go p.poll(pollEvery)
+
+	return p
+}
+
+// Get returns the VNAService driving the device with the given serial, if
+// the pool currently has one.
+func (p *DevicePool) Get(serial string) (VNAService, bool) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.devices[serial]
+
+	return entry.Service, ok
+}
+
+// Close stops polling and disconnects every device in the pool.
+func (p *DevicePool) Close() {
+	p.cancel()
+}
+
+func (p *DevicePool) poll(every time.Duration) {
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	p.reconcile()
+
+	for {
+		select {
+
+		case <-p.ctx.Done():
+			return
+
+		case <-ticker.C:
+			p.reconcile()
+		}
+	}
+}
+
+func (p *DevicePool) reconcile() {
+
+	devices, err := ListDevices()
+	if err != nil {
+		log.WithField("error", err).Warning("DevicePool: could not enumerate devices")
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seen := make(map[string]bool, len(devices))
+
+	for _, d := range devices {
+
+		seen[d.Serial] = true
+
+		if _, ok := p.devices[d.Serial]; ok {
+			continue
+		}
+
+		deviceCtx, cancel := context.WithCancel(p.ctx)
+
+		p.devices[d.Serial] = poolEntry{
+			Service: New(deviceCtx, DeviceSelector{Serial: d.Serial}),
+			cancel:  cancel,
+		}
+
+		notify(p.Added, d)
+	}
+
+	for serial, entry := range p.devices {
+
+		if seen[serial] {
+			continue
+		}
+
+		entry.cancel()
+		delete(p.devices, serial)
+
+		notify(p.Removed, DeviceInfo{Serial: serial})
+	}
+}
+
+// notify delivers d on ch without blocking, dropping it (with a log
+// message) if nothing is currently receiving. reconcile calls this while
+// holding p.mu, and not subscribing to Added/Removed is a legitimate way
+// to use a DevicePool, so a blocking send here would deadlock every
+// future Get and reconcile call - and p.cancel() could never unstick it,
+// since a plain channel send isn't a select watching ctx.Done().
+func notify(ch chan<- DeviceInfo, d DeviceInfo) {
+
+	select {
+	case ch <- d:
+	default:
+		log.WithField("serial", d.Serial).Warning("DevicePool: dropped device event, no subscriber keeping up")
+	}
+}
@@ -0,0 +1,111 @@
+package pocket
+
+/*
+#cgo CFLAGS: -g -Wall
+#cgo LDFLAGS: -L. -lPocketVnaApi_x64
+#include <stdint.h>
+#include "pocketvna.h"
+
+extern int goProgressCallback(uintptr_t id, int length, int idx);
+
+// goProgressTrampoline adapts the C-visible (void*, int, int) signature
+// PVNA_ProgressCallBack.Call expects to goProgressCallback, which takes the
+// uintptr_t cookie we actually stashed in origin - see registerProgress.
+static int goProgressTrampoline(void *origin, int length, int idx) {
+    return goProgressCallback((uintptr_t)origin, length, idx);
+}
+
+static inline PVNA_ProgressCallBack makeProgressCallback(uintptr_t id) {
+    PVNA_ProgressCallBack cb;
+    cb.origin = (void*)id;
+    cb.Call = goProgressTrampoline;
+    return cb;
+}
+*/
+import "C"
+
+import (
+	"context"
+	"sync"
+)
+
+// newProgressCallback builds a PVNA_ProgressCallBack whose origin carries
+// id rather than a real pointer, so rangeQuery (in pocket_linux_amd64.go)
+// can wire up progress reporting without that file needing its own cgo
+// declaration of PVNA_ProgressCallBack's constructor.
+func newProgressCallback(id uintptr) C.PVNA_ProgressCallBack {
+	return C.makeProgressCallback(C.uintptr_t(id))
+}
+
+// ProgressEvent reports that the device has just finished measuring
+// frequency point Index of Total in an in-flight RangeQuery.
+type ProgressEvent struct {
+	Index int
+	Total int
+}
+
+// progressTable maps the uintptr cookie passed through the C callback's
+// opaque origin pointer back to the ctx/channel pair for that RangeQuery.
+// cgo will not let us pass a Go pointer to C when what it points to holds
+// further Go pointers, so instead of handing the callback a
+// *chan ProgressEvent directly we hand it a plain integer cookie and look
+// the real values up here.
+var (
+	progressMu    sync.Mutex
+	progressNext  uintptr
+	progressTable = map[uintptr]progressTarget{}
+)
+
+type progressTarget struct {
+	ctx      context.Context
+	progress chan<- ProgressEvent
+}
+
+func registerProgress(ctx context.Context, progress chan<- ProgressEvent) uintptr {
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+
+	progressNext++
+	id := progressNext
+	progressTable[id] = progressTarget{ctx: ctx, progress: progress}
+
+	return id
+}
+
+func unregisterProgress(id uintptr) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	delete(progressTable, id)
+}
+
+//export goProgressCallback
+func goProgressCallback(id C.uintptr_t, length C.int, idx C.int) C.int {
+
+	progressMu.Lock()
+	target, ok := progressTable[uintptr(id)]
+	progressMu.Unlock()
+
+	if !ok {
+		return 0
+	}
+
+	select {
+	case target.progress <- ProgressEvent{Index: int(idx), Total: int(length)}:
+	default:
+		// receiver isn't keeping up - drop the point rather than block the device
+	}
+
+	select {
+	case <-target.ctx.Done():
+		return 1 // non-zero tells the C side to stop the sweep
+	default:
+		return 0
+	}
+}
+
+// progressCallback calls goProgressCallback with plain Go types, so the
+// package's tests, which cannot import "C" themselves, can drive it.
+func progressCallback(id uintptr, length, idx int) int {
+	return int(goProgressCallback(C.uintptr_t(id), C.int(length), C.int(idx)))
+}
@@ -0,0 +1,84 @@
+package pocket
+
+import (
+	"context"
+	"testing"
+)
+
+// TestGoProgressCallbackDeliveryOrder exercises the Go side of the
+// PVNA_ProgressCallBack bridge directly, simulating the C library calling
+// back once per frequency index, and checks the resulting ProgressEvents
+// arrive on the channel in the same order.
+func TestGoProgressCallbackDeliveryOrder(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	progress := make(chan ProgressEvent, 8)
+
+	id := registerProgress(ctx, progress)
+	defer unregisterProgress(id)
+
+	const total = 4
+
+	for i := 0; i < total; i++ {
+		if stop := progressCallback(id, total, i); stop != 0 {
+			t.Fatalf("callback requested stop before ctx was cancelled, at index %d", i)
+		}
+	}
+
+	close(progress)
+
+	i := 0
+	for ev := range progress {
+		if ev.Index != i || ev.Total != total {
+			t.Fatalf("event %d: got %+v, want {Index:%d Total:%d}", i, ev, i, total)
+		}
+		i++
+	}
+
+	if i != total {
+		t.Fatalf("got %d events, want %d", i, total)
+	}
+}
+
+// TestGoProgressCallbackStopsOnCancel checks that once the RangeQuery's
+// context is cancelled, the callback tells the C side to stop (a non-zero
+// return), and that unregisterProgress - which rangeQuery always runs via
+// defer once the call returns, whether it stopped early or not - removes
+// the registration so a later, stale callback invocation for the same id
+// (as could arrive from the device after the handle has already been
+// reused for a different sweep) is a safe no-op rather than touching
+// another request's state.
+func TestGoProgressCallbackStopsOnCancel(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	progress := make(chan ProgressEvent, 8)
+
+	id := registerProgress(ctx, progress)
+
+	if stop := progressCallback(id, 10, 0); stop != 0 {
+		t.Fatalf("callback requested stop before ctx was cancelled")
+	}
+
+	cancel() // simulate the caller aborting the in-flight RangeQuery
+
+	if stop := progressCallback(id, 10, 1); stop == 0 {
+		t.Fatalf("callback did not request stop after ctx was cancelled")
+	}
+
+	unregisterProgress(id)
+
+	progressMu.Lock()
+	_, stillRegistered := progressTable[id]
+	progressMu.Unlock()
+
+	if stillRegistered {
+		t.Fatalf("progress registration for id %d leaked past unregisterProgress", id)
+	}
+
+	if stop := progressCallback(id, 10, 2); stop != 0 {
+		t.Fatalf("callback for an unregistered id should be a no-op, got stop=%d", stop)
+	}
+}
@@ -0,0 +1,50 @@
+package pocket
+
+import (
+	"os"
+
+	"github.com/timdrysdale/go-pocketvna/pkg/touchstone"
+)
+
+// SaveTouchstone asks the VNA to write its most recent RangeQuery result to
+// Path as a Touchstone two-port file, in the given Format (RI, MA or DB;
+// empty selects RI). This lets a completed sweep be dumped for use with
+// scikit-rf, Keysight ADS, QUCS etc without any post-processing on the
+// caller's side.
+type SaveTouchstone struct {
+	Path   string
+	Format string
+}
+
+// SaveTouchstone writes the VNA's most recently completed RangeQuery
+// result to cmd.Path as a Touchstone two-port file.
+func (v *VNA) SaveTouchstone(cmd SaveTouchstone) error {
+
+	f, err := os.Create(cmd.Path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	opts := touchstone.Options{Format: cmd.Format}
+
+	return touchstone.Marshal(f, toTouchstoneParams(v.lastRangeQuery), opts)
+}
+
+func toTouchstoneParams(ss []SParam) []touchstone.SParam {
+
+	out := make([]touchstone.SParam, len(ss))
+
+	for i, s := range ss {
+		out[i] = touchstone.SParam{
+			Freq: s.Freq,
+			S11:  touchstone.Complex(s.S11),
+			S12:  touchstone.Complex(s.S12),
+			S21:  touchstone.Complex(s.S21),
+			S22:  touchstone.Complex(s.S22),
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,137 @@
+package pocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// VNAService is the channel-based handle returned by New. Request and
+// Response carry whatever command/result types VNA.HandleCommand
+// understands, Progress streams per-point RangeQuery progress (see
+// ProgressEvent), and Ctx/Timeout bound how long callers are willing to
+// wait for a reply.
+type VNAService struct {
+	VNA      *VNA
+	Ctx      context.Context
+	Request  chan interface{}
+	Response chan interface{}
+	Progress chan ProgressEvent
+	Timeout  time.Duration
+
+	reports *pendingResults
+}
+
+// Envelope wraps a command with a client-generated correlation ID.
+// VNA.Run echoes the ID back alongside the command's result, so Do (and
+// any other caller driving Request/Response directly) can demultiplex
+// concurrent in-flight requests instead of assuming replies arrive in
+// request order - a stale reply for a request Do has already given up on
+// is simply dropped rather than handed to whoever is waiting next.
+type Envelope struct {
+	ID      uint64
+	Command interface{}
+}
+
+// Do sends cmd to the VNA wrapped in an Envelope and waits for its
+// correlated reply, so that concurrent callers sharing one VNAService
+// cannot have their replies mixed up with a previous, already-timed-out
+// request.
+func (s *VNAService) Do(cmd interface{}) (interface{}, error) {
+
+	id, replies := s.reports.register()
+	defer s.reports.forget(id)
+
+	select {
+	case <-time.After(s.Timeout):
+		return nil, errors.New("timeout sending request")
+	case s.Request <- Envelope{ID: id, Command: cmd}:
+	}
+
+	select {
+	case <-time.After(s.Timeout):
+		return nil, errors.New("timeout receiving response")
+	case <-s.Ctx.Done():
+		return nil, s.Ctx.Err()
+	case result := <-replies:
+		return result, nil
+	}
+}
+
+// demux reads every reply off s.Response and routes Envelopes to whichever
+// Do call registered their ID. Replies that are not Envelopes (such as the
+// result of a command sent on Request without one) are read off Response
+// and discarded.
+func (s *VNAService) demux() {
+
+	for {
+		select {
+
+		case <-s.Ctx.Done():
+			return
+
+		case msg := <-s.Response:
+
+			e, ok := msg.(Envelope)
+			if !ok {
+				continue
+			}
+
+			s.reports.deliver(e)
+		}
+	}
+}
+
+// pendingResults tracks the in-flight Do calls of one VNAService, keyed by
+// the correlation ID each call generated, so a reply can be routed back to
+// the right waiter even when several calls are in flight at once.
+type pendingResults struct {
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan interface{}
+}
+
+func newPendingResults() *pendingResults {
+	return &pendingResults{pending: make(map[uint64]chan interface{})}
+}
+
+func (p *pendingResults) register() (uint64, chan interface{}) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	id := p.nextID
+
+	ch := make(chan interface{}, 1)
+	p.pending[id] = ch
+
+	return id, ch
+}
+
+func (p *pendingResults) forget(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, id)
+}
+
+// deliver routes e to whoever is waiting on e.ID, reporting whether anyone
+// still was - a stale reply for an ID already forgotten is simply dropped.
+func (p *pendingResults) deliver(e Envelope) bool {
+
+	p.mu.Lock()
+	ch, ok := p.pending[e.ID]
+	if ok {
+		delete(p.pending, e.ID)
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	ch <- e.Command
+
+	return true
+}
@@ -24,14 +24,46 @@ func New(u string, ctx context.Context) Switch {
 	go PipeInterfaceToWs(request, r.Out, ctx)
 	go PipeWsToInterface(r.In, response, ctx)
 
-	return Switch{
-		u:            u,
-		R:            r,
-		Ctx:          ctx,
-		Request:      request,
-		Response:     response,
-		Timeout:      2 * time.Second,
-		DrainTimeout: 10 * time.Millisecond,
+	s := Switch{
+		u:        u,
+		R:        r,
+		Ctx:      ctx,
+		Request:  request,
+		Response: response,
+		Timeout:  2 * time.Second,
+		reports:  newPendingReports(),
+	}
+
+	go s.demux()
+
+	return s
+}
+
+// demux reads every Report off s.Response and routes it to whichever
+// SetPort call registered its ID, so a reply for a request SetPort has
+// already given up on cannot be mistaken for the reply to a later,
+// still in-flight request (for a switch that echoes IDs back; see
+// pendingReports.deliver for reports carrying ID 0).
+func (s *Switch) demux() {
+
+	for {
+		select {
+
+		case <-s.Ctx.Done():
+			return
+
+		case msg := <-s.Response:
+
+			r, ok := msg.(Report)
+			if !ok {
+				// not a report message - probably a blank line, ignore
+				continue
+			}
+
+			if !s.reports.deliver(r) {
+				log.WithField("id", r.ID).Debug("dropping unmatched switch report")
+			}
+		}
 	}
 }
 
@@ -52,9 +84,14 @@ func (s *Switch) SetDUT() error {
 }
 
 func (s *Switch) SetPort(port string) error {
+
+	id, reports := s.reports.register()
+	defer s.reports.forget(id)
+
 	request := Command{
 		Set: "port",
 		To:  port,
+		ID:  id,
 	}
 
 	select {
@@ -64,42 +101,21 @@ func (s *Switch) SetPort(port string) error {
 		//carry on
 	}
 
-	for i := 0; i < 5; i++ {
-
-		select {
-		case <-time.After(s.Timeout):
-			return errors.New("timeout receiving response")
-		case response := <-s.Response:
-
-			r, ok := response.(Report)
-
-			if ok {
-
-				if r.Report == "error" {
-					return errors.New("Error" + r.Is)
-				}
-
-				if r.Report == "port" && r.Is == port {
-					return nil
-				}
-
-				// if get to here, then we have a valid response
-				// but with the wrong port, and we'll ignore it
-				// else we throw errors forever after getting one timeout.
-				// Just wait to see if a valid response is given in the
-				// right time frame.
-				// To avoid false positives, we could number requests and responses.
-
-			}
+	select {
+	case <-time.After(s.Timeout):
+		return errors.New("timeout receiving response")
+	case r := <-reports:
 
-			// not a report message - probably a blank line, ignore
+		if r.Report == "error" {
+			return errors.New("Error" + r.Is)
 		}
-	}
 
-	// if we get to here, too many blank lines or non-standard
-	// messages were sent - check arduino software and USB connection?
-	return errors.New("Too many Unexpected responses")
+		if r.Report == "port" && r.Is == port {
+			return nil
+		}
 
+		return fmt.Errorf("unexpected response: report %q is %q", r.Report, r.Is)
+	}
 }
 
 func PipeInterfaceToWs(in chan interface{}, out chan reconws.WsMessage, ctx context.Context) {
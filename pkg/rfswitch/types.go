@@ -0,0 +1,127 @@
+package rfswitch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/timdrysdale/go-pocketvna/pkg/reconws"
+)
+
+// Switch drives an RF switch (short/open/load/DUT) over a websocket,
+// sending Command messages and demultiplexing the Report replies by
+// correlation ID (see Command.ID / pendingReports) so concurrent in-flight
+// requests cannot be confused with one another.
+type Switch struct {
+	u        string
+	R        *reconws.Reconws
+	Ctx      context.Context
+	Request  chan interface{}
+	Response chan interface{}
+	Timeout  time.Duration
+
+	reports *pendingReports
+}
+
+// Command asks the switch to change state, e.g. {Set: "port", To: "short"}.
+// ID is a client-generated, monotonically increasing correlation ID: the
+// server echoes it back on the matching Report so a reply arriving after
+// its request has already timed out cannot be mistaken for the reply to a
+// later, still in-flight request.
+type Command struct {
+	Set string
+	To  string
+	ID  uint64
+}
+
+// Report is the switch's reply to a Command, e.g. {Report: "port", Is:
+// "short"}, echoing back the ID of the Command it answers.
+type Report struct {
+	Report string
+	Is     string
+	ID     uint64
+}
+
+// pendingReports tracks the in-flight SetPort calls of one Switch, keyed
+// by the correlation ID each call generated. order records registration
+// order so deliver can still find the right caller against firmware that
+// does not echo ID back (every field it doesn't know about unmarshals to
+// its zero value, so an unmodified switch's Report always has ID: 0).
+type pendingReports struct {
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan Report
+	order   []uint64
+}
+
+func newPendingReports() *pendingReports {
+	return &pendingReports{pending: make(map[uint64]chan Report)}
+}
+
+func (p *pendingReports) register() (uint64, chan Report) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	id := p.nextID
+
+	ch := make(chan Report, 1)
+	p.pending[id] = ch
+	p.order = append(p.order, id)
+
+	return id, ch
+}
+
+func (p *pendingReports) forget(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, id)
+	p.removeOrder(id)
+}
+
+func (p *pendingReports) removeOrder(id uint64) {
+	for i, pending := range p.order {
+		if pending == id {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// deliver routes r to whoever is waiting on it, reporting whether anyone
+// still was - a stale reply for an ID already forgotten is simply dropped.
+// A non-zero r.ID is matched exactly, against an updated switch that
+// echoes it back. r.ID == 0 instead falls back to the oldest still-pending
+// call, so this keeps working against an unmodified switch that has never
+// heard of correlation IDs and always reports ID: 0. Against such a switch
+// a stale reply is handed to the oldest pending call whatever its port,
+// and SetPort then returns an unexpected-response error rather than
+// waiting for a matching one; concurrent SetPort calls cannot be told apart.
+func (p *pendingReports) deliver(r Report) bool {
+
+	p.mu.Lock()
+
+	id := r.ID
+	ch, ok := p.pending[id]
+
+	if !ok && r.ID == 0 && len(p.order) > 0 {
+		id = p.order[0]
+		ch, ok = p.pending[id]
+	}
+
+	if ok {
+		delete(p.pending, id)
+		p.removeOrder(id)
+	}
+
+	p.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	ch <- r
+
+	return true
+}
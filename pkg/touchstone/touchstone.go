@@ -0,0 +1,300 @@
+/*
+Package touchstone reads and writes the Touchstone v1 file format used
+across the RF ecosystem (scikit-rf, Keysight ADS, QUCS, ...) for exchanging
+S-parameter data, e.g. ".s1p"/".s2p" files.
+
+A file is a single "# <unit> S <format> R <r>" option line followed by one
+row per frequency. Two-port (.s2p) rows are
+
+	f Re(S11) Im(S11) Re(S21) Im(S21) Re(S12) Im(S12) Re(S22) Im(S22)
+
+Note the swapped column order (S11, S21, S12, S22) relative to the row
+order SParam itself uses - that is the Touchstone convention, not a typo.
+One-port (.s1p) rows only carry S11:
+
+	f Re(S11) Im(S11)
+
+Marshal writes two-port rows unless Options.Ports is set to 1; Unmarshal
+detects which layout a file uses from its row width and reports it back
+via the returned Options.Ports, leaving S12/S21/S22 as the zero Complex
+for a one-port file.
+
+The package defines its own SParam/Complex types, structurally identical
+to pocket's, so that callers (such as pkg/pocket) can convert with a plain
+type conversion rather than this package depending on pocket.
+*/
+package touchstone
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Complex is a rectangular complex number, structurally identical to
+// pocket.Complex.
+type Complex struct {
+	Real float64
+	Imag float64
+}
+
+// SParam is a single frequency point of two-port S-parameters,
+// structurally identical to pocket.SParam.
+type SParam struct {
+	S11  Complex
+	S12  Complex
+	S21  Complex
+	S22  Complex
+	Freq uint64
+}
+
+// Options describes the frequency unit, parameter format, reference
+// impedance and port count used in a Touchstone file.
+type Options struct {
+	Unit   string // Hz, kHz, MHz or GHz; defaults to Hz
+	Format string // RI, MA or DB; defaults to RI
+	R      int    // reference impedance in ohms; defaults to 50
+	Ports  int    // 1 (.s1p, S11 only) or 2 (.s2p); defaults to 2
+}
+
+func (o Options) withDefaults() Options {
+
+	if o.Unit == "" {
+		o.Unit = "Hz"
+	}
+	if o.Format == "" {
+		o.Format = "RI"
+	}
+	if o.R == 0 {
+		o.R = 50
+	}
+	if o.Ports == 0 {
+		o.Ports = 2
+	}
+
+	return o
+}
+
+var unitScale = map[string]float64{
+	"HZ":  1,
+	"KHZ": 1e3,
+	"MHZ": 1e6,
+	"GHZ": 1e9,
+}
+
+// Marshal writes ss to w as a Touchstone file, using opts to choose the
+// frequency unit, parameter format (RI/MA/DB), reference impedance and
+// port count (1 for .s1p - S11 only, 2 for .s2p). Zero-value fields in
+// opts fall back to Hz/RI/50R/2-port.
+func Marshal(w io.Writer, ss []SParam, opts Options) error {
+
+	opts = opts.withDefaults()
+
+	if opts.Ports != 1 && opts.Ports != 2 {
+		return fmt.Errorf("touchstone: unsupported port count %d", opts.Ports)
+	}
+
+	scale, ok := unitScale[strings.ToUpper(opts.Unit)]
+	if !ok {
+		return fmt.Errorf("touchstone: unknown unit %q", opts.Unit)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# %s S %s R %d\n", strings.ToUpper(opts.Unit), strings.ToUpper(opts.Format), opts.R)
+
+	for _, s := range ss {
+
+		f := float64(s.Freq) / scale
+
+		if opts.Ports == 1 {
+			fmt.Fprintf(bw, "%g %s\n", f, formatParam(s.S11, opts.Format))
+			continue
+		}
+
+		fmt.Fprintf(bw, "%g %s %s %s %s\n",
+			f,
+			formatParam(s.S11, opts.Format),
+			formatParam(s.S21, opts.Format),
+			formatParam(s.S12, opts.Format),
+			formatParam(s.S22, opts.Format),
+		)
+	}
+
+	return bw.Flush()
+}
+
+// Unmarshal reads a one- or two-port Touchstone file from r, returning the
+// decoded S-parameters and the Options the file declared in its option
+// line (or Touchstone's Hz/RI/50R defaults, if the file omits one).
+// Options.Ports is set from the row width, not the option line - a
+// one-port file leaves S12/S21/S22 as the zero Complex.
+func Unmarshal(r io.Reader) ([]SParam, Options, error) {
+
+	opts := Options{}.withDefaults()
+	scale := unitScale["HZ"]
+
+	var ss []SParam
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+
+			o, err := parseOptionLine(line)
+			if err != nil {
+				return nil, opts, err
+			}
+
+			opts = o
+
+			var ok bool
+			scale, ok = unitScale[strings.ToUpper(opts.Unit)]
+			if !ok {
+				return nil, opts, fmt.Errorf("touchstone: unknown unit %q", opts.Unit)
+			}
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		switch len(fields) {
+		case 3, 9:
+			// one-port (f + S11) or two-port (f + S11/S21/S12/S22) row
+		default:
+			return nil, opts, fmt.Errorf("touchstone: expected 3 (1-port) or 9 (2-port) fields per row, got %d: %q", len(fields), line)
+		}
+
+		values := make([]float64, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, opts, fmt.Errorf("touchstone: invalid number %q: %w", f, err)
+			}
+			values[i] = v
+		}
+
+		s := SParam{
+			Freq: uint64(math.Round(values[0] * scale)),
+			S11:  parseParam(values[1], values[2], opts.Format),
+		}
+
+		if len(fields) == 9 {
+			opts.Ports = 2
+			s.S21 = parseParam(values[3], values[4], opts.Format)
+			s.S12 = parseParam(values[5], values[6], opts.Format)
+			s.S22 = parseParam(values[7], values[8], opts.Format)
+		} else {
+			opts.Ports = 1
+		}
+
+		ss = append(ss, s)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, opts, err
+	}
+
+	return ss, opts, nil
+}
+
+func parseOptionLine(line string) (Options, error) {
+
+	opts := Options{Unit: "Hz", Format: "RI", R: 50}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#"))
+
+	for i := 0; i < len(fields); i++ {
+
+		switch strings.ToUpper(fields[i]) {
+
+		case "HZ", "KHZ", "MHZ", "GHZ":
+			opts.Unit = fields[i]
+
+		case "S":
+			// parameter type - this package only handles S-parameters
+
+		case "RI", "MA", "DB":
+			opts.Format = fields[i]
+
+		case "R":
+			if i+1 >= len(fields) {
+				return opts, errors.New("touchstone: R option with no value")
+			}
+
+			r, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				return opts, fmt.Errorf("touchstone: invalid R value %q: %w", fields[i+1], err)
+			}
+
+			opts.R = r
+			i++
+		}
+	}
+
+	return opts, nil
+}
+
+func formatParam(c Complex, format string) string {
+
+	switch strings.ToUpper(format) {
+
+	case "MA":
+		mag, ang := toMA(c)
+		return fmt.Sprintf("%g %g", mag, ang)
+
+	case "DB":
+		db, ang := toDB(c)
+		return fmt.Sprintf("%g %g", db, ang)
+
+	default: // RI
+		return fmt.Sprintf("%g %g", c.Real, c.Imag)
+	}
+}
+
+func parseParam(a, b float64, format string) Complex {
+
+	switch strings.ToUpper(format) {
+
+	case "MA":
+		return fromMA(a, b)
+
+	case "DB":
+		return fromDB(a, b)
+
+	default: // RI
+		return Complex{Real: a, Imag: b}
+	}
+}
+
+func toMA(c Complex) (mag, angleDeg float64) {
+	mag = math.Hypot(c.Real, c.Imag)
+	angleDeg = math.Atan2(c.Imag, c.Real) * 180 / math.Pi
+	return mag, angleDeg
+}
+
+func toDB(c Complex) (db, angleDeg float64) {
+	mag, ang := toMA(c)
+	return 20 * math.Log10(mag), ang
+}
+
+func fromMA(mag, angleDeg float64) Complex {
+	rad := angleDeg * math.Pi / 180
+	return Complex{Real: mag * math.Cos(rad), Imag: mag * math.Sin(rad)}
+}
+
+func fromDB(db, angleDeg float64) Complex {
+	return fromMA(math.Pow(10, db/20), angleDeg)
+}
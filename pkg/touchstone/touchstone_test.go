@@ -0,0 +1,112 @@
+package touchstone
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func closeEnough(a, b Complex) bool {
+	const tol = 1e-6
+	return math.Abs(a.Real-b.Real) < tol && math.Abs(a.Imag-b.Imag) < tol
+}
+
+// TestRoundTripPorts checks that Marshal followed by Unmarshal recovers the
+// original S-parameters for both the one-port (.s1p) and two-port (.s2p)
+// row layouts, and that Unmarshal reports back the port count it saw.
+func TestRoundTripPorts(t *testing.T) {
+
+	cases := []struct {
+		name  string
+		ports int
+		ss    []SParam
+	}{
+		{
+			name:  "one-port",
+			ports: 1,
+			ss: []SParam{
+				{Freq: 1000000, S11: Complex{Real: 0.1, Imag: -0.2}},
+				{Freq: 2000000, S11: Complex{Real: -0.3, Imag: 0.4}},
+			},
+		},
+		{
+			name:  "two-port",
+			ports: 2,
+			ss: []SParam{
+				{Freq: 1000000, S11: Complex{1, 2}, S21: Complex{3, 4}, S12: Complex{5, 6}, S22: Complex{7, 8}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			var buf bytes.Buffer
+
+			if err := Marshal(&buf, c.ss, Options{Ports: c.ports}); err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			got, opts, err := Unmarshal(&buf)
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if opts.Ports != c.ports {
+				t.Fatalf("Options.Ports = %d, want %d", opts.Ports, c.ports)
+			}
+
+			if len(got) != len(c.ss) {
+				t.Fatalf("got %d points, want %d", len(got), len(c.ss))
+			}
+
+			for i, want := range c.ss {
+				if got[i] != want {
+					t.Fatalf("point %d: got %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestRoundTripFormats checks that Marshal/Unmarshal round-trip an S11
+// value through each of the RI, MA and DB parameter formats without
+// meaningful precision loss.
+func TestRoundTripFormats(t *testing.T) {
+
+	s11 := Complex{Real: 0.2, Imag: -0.35}
+
+	for _, format := range []string{"RI", "MA", "DB"} {
+		t.Run(format, func(t *testing.T) {
+
+			ss := []SParam{{Freq: 1000000, S11: s11}}
+
+			var buf bytes.Buffer
+
+			if err := Marshal(&buf, ss, Options{Ports: 1, Format: format}); err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			got, _, err := Unmarshal(&buf)
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if !closeEnough(got[0].S11, s11) {
+				t.Fatalf("format %s: got %+v, want %+v", format, got[0].S11, s11)
+			}
+		})
+	}
+}
+
+// TestMarshalRejectsBadPortCount checks that Marshal refuses a port count
+// it doesn't understand rather than silently writing a malformed file.
+func TestMarshalRejectsBadPortCount(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	err := Marshal(&buf, []SParam{{Freq: 1}}, Options{Ports: 3})
+	if err == nil {
+		t.Fatal("expected an error for Ports: 3, got nil")
+	}
+}